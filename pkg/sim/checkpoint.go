@@ -0,0 +1,88 @@
+package sim
+
+/*
+#include "shim_binding.h"
+#include "shim.h"
+*/
+import "C"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// checkpointMagic identifies the binary checkpoint format written by
+// Checkpoint and read back by Restore.
+const checkpointMagic = "PEBBCKPT"
+
+// Checkpoint serializes the Simulation's current state to w: the cycle
+// counter, the current VCD trace offset, and the Verilated model's
+// flip-flop/memory state as dumped by the companion
+// checkpoint_simulation C entrypoint. The result can later be handed to
+// Restore to resume from exactly this point, which is much faster than
+// re-running the boot sequence when bisecting a routing bug.
+func (s *Simulation) Checkpoint(w io.Writer) error {
+	var size C.size_t
+	C.checkpoint_simulation(s.handle, nil, &size)
+	if size == 0 {
+		return fmt.Errorf("sim: checkpoint_simulation reported zero-size state")
+	}
+
+	buf := make([]byte, size)
+	C.checkpoint_simulation(s.handle, (*C.uint8_t)(unsafe.Pointer(&buf[0])), &size)
+
+	if _, err := io.WriteString(w, checkpointMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(s.handle.cycleno)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(C.vcd_offset(s.handle))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(buf))); err != nil {
+		return err
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Restore replaces the Simulation's current state with a checkpoint
+// previously written by Checkpoint, including the cycle counter and VCD
+// trace offset.
+func (s *Simulation) Restore(r io.Reader) error {
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return fmt.Errorf("sim: read checkpoint magic: %w", err)
+	}
+	if string(magic) != checkpointMagic {
+		return fmt.Errorf("sim: not a pebb-routing checkpoint file")
+	}
+
+	var cycle, vcdOffset, stateLen uint64
+	if err := binary.Read(r, binary.LittleEndian, &cycle); err != nil {
+		return fmt.Errorf("sim: read cycle counter: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &vcdOffset); err != nil {
+		return fmt.Errorf("sim: read VCD offset: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &stateLen); err != nil {
+		return fmt.Errorf("sim: read state length: %w", err)
+	}
+	if stateLen == 0 {
+		return fmt.Errorf("sim: checkpoint has zero-length state")
+	}
+
+	buf := make([]byte, stateLen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("sim: read state: %w", err)
+	}
+
+	ok := C.restore_simulation(s.handle, (*C.uint8_t)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)), C.uint64_t(cycle), C.uint64_t(vcdOffset))
+	if ok == 0 {
+		return fmt.Errorf("sim: restore_simulation rejected checkpoint")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sim
+
+/*
+#include "shim_binding.h"
+#include "shim.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Probe is a handle to a named signal registered with the Verilated
+// model. It replaces one-off accessors like the C get_foo getter, letting
+// callers inspect arbitrary DUT state without a dedicated Go/C getter
+// for every signal.
+type Probe struct {
+	sim    *Simulation
+	name   string
+	width  uint
+	handle C.probe_t
+}
+
+// Probe registers (or looks up an existing registration for) the named
+// signal on the DUT and returns a handle for reading it. width is the
+// signal's width in bits. Calling Probe again for an already-registered
+// name must pass the same width it was first registered with.
+func (s *Simulation) Probe(name string, width uint) (*Probe, error) {
+	if p, ok := s.probes[name]; ok {
+		if p.width != width {
+			return nil, fmt.Errorf("sim: probe %q already registered with width %d, got %d", name, p.width, width)
+		}
+		return p, nil
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	handle := C.register_probe(s.handle, cname, C.uint32_t(width))
+	if handle == nil {
+		return nil, fmt.Errorf("sim: probe %q not found on DUT", name)
+	}
+
+	p := &Probe{sim: s, name: name, width: width, handle: handle}
+	if s.probes == nil {
+		s.probes = make(map[string]*Probe)
+	}
+	s.probes[name] = p
+	s.registered = append(s.registered, p)
+
+	return p, nil
+}
+
+// RegisteredProbes returns every Probe registered on this Simulation so
+// far, in registration order.
+func (s *Simulation) RegisteredProbes() []*Probe {
+	return s.registered
+}
+
+// ListProbes enumerates every signal the Verilated model exposes for
+// probing, independent of whether it has been registered with Probe
+// yet.
+func (s *Simulation) ListProbes() ([]string, error) {
+	count := C.list_probes(s.handle, nil, 0)
+	if count == 0 {
+		return nil, nil
+	}
+
+	cnames := make([]*C.char, count)
+	C.list_probes(s.handle, &cnames[0], count)
+
+	names := make([]string, count)
+	for i, cn := range cnames {
+		names[i] = C.GoString(cn)
+	}
+	return names, nil
+}
+
+// Name returns the signal name this Probe was registered with.
+func (p *Probe) Name() string {
+	return p.name
+}
+
+// Read samples the probe's current value.
+//
+// For signals wider than 64 bits, use ReadBytes instead.
+func (p *Probe) Read() uint64 {
+	return uint64(C.read_probe(p.sim.handle, p.handle))
+}
+
+// ReadBytes samples the probe's current value as a little-endian byte
+// slice sized to its width, for signals too wide to fit in a uint64.
+func (p *Probe) ReadBytes() []byte {
+	buf := make([]byte, (p.width+7)/8)
+	C.read_probe_bytes(p.sim.handle, p.handle, (*C.uint8_t)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
+	return buf
+}
+
+// probeFor returns the cached Probe for signal, registering a new
+// 64-bit probe if one is not already registered under that name.
+func (s *Simulation) probeFor(signal string) (*Probe, error) {
+	if p, ok := s.probes[signal]; ok {
+		return p, nil
+	}
+	return s.Probe(signal, 64)
+}
@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLoadScenario(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  Scenario
+	}{
+		{
+			name:  "pokes and expectations",
+			input: `{"name":"boot","pokes":[{"cycle":0,"signal":"foo","value":1}],"expectations":[{"cycle":5,"signal":"foo","value":1}]}`,
+			want: Scenario{
+				Name:         "boot",
+				Pokes:        []Poke{{Cycle: 0, Signal: "foo", Value: 1}},
+				Expectations: []Expectation{{Cycle: 5, Signal: "foo", Value: 1}},
+			},
+		},
+		{
+			name:  "no pokes",
+			input: `{"name":"idle","expectations":[{"cycle":10,"signal":"foo","value":0}]}`,
+			want: Scenario{
+				Name:         "idle",
+				Expectations: []Expectation{{Cycle: 10, Signal: "foo", Value: 0}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := LoadScenario(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("LoadScenario: %v", err)
+			}
+			if got.Name != tc.want.Name {
+				t.Errorf("Name = %q, want %q", got.Name, tc.want.Name)
+			}
+			if !slicesEqual(got.Pokes, tc.want.Pokes) {
+				t.Errorf("Pokes = %+v, want %+v", got.Pokes, tc.want.Pokes)
+			}
+			if !slicesEqual(got.Expectations, tc.want.Expectations) {
+				t.Errorf("Expectations = %+v, want %+v", got.Expectations, tc.want.Expectations)
+			}
+		})
+	}
+}
+
+func TestLoadScenarioInvalidJSON(t *testing.T) {
+	if _, err := LoadScenario(strings.NewReader("{not json")); err == nil {
+		t.Fatal("LoadScenario: want error for invalid JSON, got nil")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report := &Report{
+		Name: "boot",
+		Results: []Result{
+			{Expectation: Expectation{Cycle: 5, Signal: "foo", Value: 1}, Actual: 1, Pass: true},
+			{Expectation: Expectation{Cycle: 10, Signal: "foo", Value: 2}, Actual: 1, Pass: false},
+		},
+		Pass: false,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, report); err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode written report: %v", err)
+	}
+
+	if got.Name != report.Name || got.Pass != report.Pass {
+		t.Errorf("Name/Pass = %q/%v, want %q/%v", got.Name, got.Pass, report.Name, report.Pass)
+	}
+	if !slicesEqual(got.Results, report.Results) {
+		t.Errorf("Results = %+v, want %+v", got.Results, report.Results)
+	}
+}
+
+func slicesEqual[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
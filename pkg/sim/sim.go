@@ -0,0 +1,101 @@
+// Package sim wraps the cgo bindings to the Verilated router model,
+// exposing a Go-native API for driving simulation cycles in place of
+// the raw C entry points.
+package sim
+
+/*
+#include "shim_binding.h"
+#include "shim.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Mode selects how the underlying Verilated model is driven.
+type Mode string
+
+const (
+	// ModeGUI runs the Verilated model with its waveform/GUI support
+	// enabled.
+	ModeGUI Mode = "gui"
+	// ModeBatch runs the Verilated model headless, which is the right
+	// choice for CI and scripted runs.
+	ModeBatch Mode = "batch"
+)
+
+// Options configures a Simulation returned by New.
+type Options struct {
+	// TracePath is the VCD file the simulation will write to. If empty,
+	// no trace is recorded.
+	TracePath string
+
+	// Mode selects whether the Verilated model runs with a GUI or
+	// headless. Defaults to ModeBatch.
+	Mode Mode
+
+	// Args are passed through to the underlying Verilated model as
+	// additional argv entries, after the mode flag.
+	Args []string
+}
+
+// Simulation wraps a running instance of the Verilated router model.
+type Simulation struct {
+	handle *C.sim_t
+	opts   Options
+
+	probes     map[string]*Probe
+	registered []*Probe
+}
+
+// New initializes a new Simulation according to opts.
+func New(opts Options) (*Simulation, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeBatch
+	}
+
+	argv := make([]*C.char, 0, 1+len(opts.Args))
+
+	modeArg := C.CString(string(opts.Mode))
+	defer C.free(unsafe.Pointer(modeArg))
+	argv = append(argv, modeArg)
+
+	for _, a := range opts.Args {
+		cs := C.CString(a)
+		defer C.free(unsafe.Pointer(cs))
+		argv = append(argv, cs)
+	}
+
+	tracePath := C.CString(opts.TracePath)
+	defer C.free(unsafe.Pointer(tracePath))
+
+	handle := C.initialize_simulation(C.int(len(argv)), &argv[0], tracePath)
+	if handle == nil {
+		return nil, fmt.Errorf("sim: initialize_simulation returned nil")
+	}
+
+	return &Simulation{handle: handle, opts: opts}, nil
+}
+
+// RunCycles advances the simulation by n clock cycles.
+func (s *Simulation) RunCycles(n uint64) {
+	C.run_cycles(s.handle, C.uint64_t(n))
+}
+
+// Cycle returns the number of cycles the simulation has run so far.
+func (s *Simulation) Cycle() uint64 {
+	return uint64(s.handle.cycleno)
+}
+
+// Close releases the resources held by the underlying Verilated model.
+// The Simulation must not be used after Close returns.
+func (s *Simulation) Close() error {
+	if s.handle == nil {
+		return nil
+	}
+	C.finalize_simulation(s.handle)
+	s.handle = nil
+	return nil
+}
@@ -0,0 +1,34 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// BenchmarkRunTraced measures RunTraced's per-cycle cost (b.N is the
+// number of cycles run) at several batch sizes. Each run_cycles call,
+// probe sample and Record is shared by batchSize cycles, so the Go/C
+// boundary cost per cycle shrinks as batchSize grows.
+func BenchmarkRunTraced(b *testing.B) {
+	for _, batchSize := range []uint64{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			s, err := New(Options{})
+			if err != nil {
+				b.Fatalf("initialize simulation: %v", err)
+			}
+			defer s.Close()
+
+			if _, err := s.Probe("foo", 64); err != nil {
+				b.Fatalf("register probe: %v", err)
+			}
+
+			sink := NewTextSink(io.Discard)
+
+			b.ResetTimer()
+			if err := s.RunTraced(uint64(b.N), batchSize, sink); err != nil {
+				b.Fatalf("run traced: %v", err)
+			}
+		})
+	}
+}
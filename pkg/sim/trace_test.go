@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestTextSinkWrite(t *testing.T) {
+	cases := []struct {
+		name string
+		rec  Record
+		want string
+	}{
+		{
+			name: "no probes",
+			rec:  Record{Cycle: 3},
+			want: "cycleno: 3\n",
+		},
+		{
+			name: "multiple probes",
+			rec: Record{Cycle: 3, Probes: []ProbeSample{
+				{Name: "foo", Value: 42},
+				{Name: "bar", Value: 7},
+			}},
+			want: "cycleno: 3, foo=42, bar=7\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			sink := NewTextSink(&buf)
+			if err := sink.Write(tc.rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("output = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONSinkWrite(t *testing.T) {
+	rec := Record{Cycle: 3, Probes: []ProbeSample{
+		{Name: "foo", Value: 42},
+		{Name: "bar", Value: 7},
+	}}
+
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+	if err := sink.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var got struct {
+		Cycle  uint64            `json:"cycle"`
+		Probes map[string]uint64 `json:"probes"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode written record: %v", err)
+	}
+
+	if got.Cycle != rec.Cycle {
+		t.Errorf("Cycle = %d, want %d", got.Cycle, rec.Cycle)
+	}
+	want := map[string]uint64{"foo": 42, "bar": 7}
+	if len(got.Probes) != len(want) {
+		t.Fatalf("Probes = %+v, want %+v", got.Probes, want)
+	}
+	for name, value := range want {
+		if got.Probes[name] != value {
+			t.Errorf("Probes[%q] = %d, want %d", name, got.Probes[name], value)
+		}
+	}
+}
+
+func TestBinarySinkWrite(t *testing.T) {
+	rec := Record{Cycle: 3, Probes: []ProbeSample{
+		{Name: "foo", Value: 42},
+		{Name: "bar", Value: 7},
+	}}
+
+	var buf bytes.Buffer
+	sink := NewBinarySink(&buf)
+	if err := sink.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	data := buf.Bytes()
+
+	magic := data[:len(binaryMagic)]
+	if string(magic) != binaryMagic {
+		t.Fatalf("magic = %q, want %q", magic, binaryMagic)
+	}
+	data = data[len(binaryMagic):]
+
+	cycle := binary.LittleEndian.Uint64(data)
+	if cycle != rec.Cycle {
+		t.Errorf("cycle = %d, want %d", cycle, rec.Cycle)
+	}
+	data = data[8:]
+
+	count := binary.LittleEndian.Uint32(data)
+	if int(count) != len(rec.Probes) {
+		t.Fatalf("probe count = %d, want %d", count, len(rec.Probes))
+	}
+	data = data[4:]
+
+	for _, want := range rec.Probes {
+		nameLen := binary.LittleEndian.Uint16(data)
+		data = data[2:]
+		name := string(data[:nameLen])
+		data = data[nameLen:]
+		value := binary.LittleEndian.Uint64(data)
+		data = data[8:]
+
+		if name != want.Name || value != want.Value {
+			t.Errorf("probe = %s=%d, want %s=%d", name, value, want.Name, want.Value)
+		}
+	}
+
+	if len(data) != 0 {
+		t.Errorf("%d trailing bytes after decoding all probes", len(data))
+	}
+}
+
+func TestBinarySinkWriteHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewBinarySink(&buf)
+
+	if err := sink.Write(Record{Cycle: 0}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Write(Record{Cycle: 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	data := buf.Bytes()
+	if n := bytes.Count(data, []byte(binaryMagic)); n != 1 {
+		t.Errorf("magic appears %d times, want 1", n)
+	}
+}
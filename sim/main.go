@@ -1,22 +1,237 @@
+// Command sim drives the Verilated router model for interactive or
+// scripted use.
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/HeRCLab/pebb-routing/pkg/sim"
 )
 
-//#include "shim_binding.h"
-//#include "shim.h"
-import "C"
+// probeFlag accumulates repeated -probe name:width values.
+type probeFlag []string
+
+func (p *probeFlag) String() string { return strings.Join(*p, ",") }
+
+func (p *probeFlag) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
 
 func main() {
-	argc := C.int(1)
-	argv := make([]*C.char, 1)
-	argv[0] = C.CString("gui")
-	s := C.initialize_simulation(argc, &(argv[0]), C.CString("trace.vcd"))
+	trace := flag.String("trace", "trace.vcd", "path to write the VCD trace to")
+	cycles := flag.Uint64("cycles", 100, "number of cycles to run")
+	quiet := flag.Bool("quiet", false, "suppress per-cycle output")
+	args := flag.String("args", "", "comma-separated arguments passed through to the Verilated model")
+	mode := flag.String("mode", string(sim.ModeBatch), "simulation mode: gui or batch")
+	scenarioPath := flag.String("scenario", "", "path to a JSON scenario file describing pokes and expectations; if set, replaces the default cycle loop")
+	resultsPath := flag.String("results", "results.json", "path to write the scenario report to, when -scenario is set")
+	restoreFrom := flag.String("restore", "", "path to a checkpoint file to restore from before running")
+	checkpointAt := flag.Uint64("checkpoint-at", 0, "number of cycles into this run (after any -restore) at which to write a checkpoint (0 disables checkpointing)")
+	checkpointOut := flag.String("checkpoint-out", "checkpoint.bin", "path to write the checkpoint to, when -checkpoint-at is set")
+	sinkFormat := flag.String("sink", "text", "trace output format: text, json, or binary")
+	batchSize := flag.Uint64("batch", 1, "number of cycles to run per trace sample, for high cycle counts")
+	var probeSpecs probeFlag
+	flag.Var(&probeSpecs, "probe", "name:width of a signal to probe and print each cycle (repeatable); defaults to foo:64")
+	flag.Parse()
+
+	simMode, err := parseMode(*mode)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var passthrough []string
+	if *args != "" {
+		passthrough = strings.Split(*args, ",")
+	}
+
+	s, err := sim.New(sim.Options{
+		TracePath: *trace,
+		Mode:      simMode,
+		Args:      passthrough,
+	})
+	if err != nil {
+		log.Fatalf("initialize simulation: %v", err)
+	}
+	defer s.Close()
+
+	if *restoreFrom != "" {
+		if err := restoreCheckpoint(s, *restoreFrom); err != nil {
+			log.Fatalf("restore checkpoint: %v", err)
+		}
+	}
+
+	if *scenarioPath != "" {
+		runScenario(s, *scenarioPath, *resultsPath)
+		return
+	}
+
+	if err := registerProbes(s, probeSpecs); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	sink, err := newSink(*sinkFormat, *quiet)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer sink.Close()
+
+	if *checkpointAt > *cycles {
+		log.Fatalf("-checkpoint-at %d is past the end of the %d-cycle run", *checkpointAt, *cycles)
+	}
+
+	if *checkpointAt != 0 && *checkpointAt < *cycles {
+		if err := s.RunTraced(*checkpointAt, *batchSize, sink); err != nil {
+			log.Fatalf("run cycles: %v", err)
+		}
+		if err := writeCheckpoint(s, *checkpointOut); err != nil {
+			log.Fatalf("write checkpoint: %v", err)
+		}
+		if err := s.RunTraced(*cycles-*checkpointAt, *batchSize, sink); err != nil {
+			log.Fatalf("run cycles: %v", err)
+		}
+		return
+	}
+
+	if err := s.RunTraced(*cycles, *batchSize, sink); err != nil {
+		log.Fatalf("run cycles: %v", err)
+	}
+
+	if *checkpointAt != 0 && *checkpointAt == *cycles {
+		if err := writeCheckpoint(s, *checkpointOut); err != nil {
+			log.Fatalf("write checkpoint: %v", err)
+		}
+	}
+}
+
+// registerProbes registers each "name:width" spec in specs, defaulting
+// to a single foo:64 probe when specs is empty.
+func registerProbes(s *sim.Simulation, specs []string) error {
+	if len(specs) == 0 {
+		specs = []string{"foo:64"}
+	}
+	for _, spec := range specs {
+		name, width, err := parseProbeSpec(spec)
+		if err != nil {
+			return err
+		}
+		if _, err := s.Probe(name, width); err != nil {
+			return fmt.Errorf("register probe %q: %w", spec, err)
+		}
+	}
+	return nil
+}
+
+// parseProbeSpec splits a "name:width" -probe flag value.
+func parseProbeSpec(spec string) (string, uint, error) {
+	name, widthStr, ok := strings.Cut(spec, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid -probe %q, want name:width", spec)
+	}
+	width, err := strconv.ParseUint(widthStr, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid -probe %q: %w", spec, err)
+	}
+	return name, uint(width), nil
+}
+
+// parseMode validates a -mode flag value against the modes sim.New
+// accepts.
+func parseMode(mode string) (sim.Mode, error) {
+	switch sim.Mode(mode) {
+	case sim.ModeGUI:
+		return sim.ModeGUI, nil
+	case sim.ModeBatch:
+		return sim.ModeBatch, nil
+	default:
+		return "", fmt.Errorf("unknown -mode %q, want gui or batch", mode)
+	}
+}
+
+// newSink builds the TraceSink named by format, writing to stdout
+// unless quiet suppresses output entirely.
+func newSink(format string, quiet bool) (sim.TraceSink, error) {
+	w := io.Writer(os.Stdout)
+	if quiet {
+		w = io.Discard
+	}
+
+	switch format {
+	case "text":
+		return sim.NewTextSink(w), nil
+	case "json":
+		return sim.NewJSONSink(w), nil
+	case "binary":
+		return sim.NewBinarySink(w), nil
+	default:
+		return nil, fmt.Errorf("unknown -sink format %q", format)
+	}
+}
+
+// restoreCheckpoint loads the checkpoint file at path and restores s to
+// the state it describes.
+func restoreCheckpoint(s *sim.Simulation, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Restore(f)
+}
+
+// writeCheckpoint snapshots s's current state to path.
+func writeCheckpoint(s *sim.Simulation, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.Checkpoint(f); err != nil {
+		return err
+	}
+	fmt.Printf("wrote checkpoint at cycle %d to %s\n", s.Cycle(), path)
+	return nil
+}
+
+// runScenario loads the scenario at scenarioPath, runs it against s, writes
+// the resulting report to resultsPath, and exits the process with a
+// non-zero status if any expectation failed.
+func runScenario(s *sim.Simulation, scenarioPath, resultsPath string) {
+	f, err := os.Open(scenarioPath)
+	if err != nil {
+		log.Fatalf("open scenario: %v", err)
+	}
+	sc, err := sim.LoadScenario(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("load scenario: %v", err)
+	}
+
+	report, err := s.Run(sc)
+	if err != nil {
+		log.Fatalf("run scenario: %v", err)
+	}
+
+	out, err := os.Create(resultsPath)
+	if err != nil {
+		log.Fatalf("create results file: %v", err)
+	}
+	defer out.Close()
+	if err := sim.WriteReport(out, report); err != nil {
+		log.Fatalf("write results: %v", err)
+	}
 
-	for i := 0; i < 100; i++ {
-		C.run_cycles(s, 1)
-		fmt.Printf("cycleno: %d, foo=%d\n", s.cycleno, C.get_foo(s))
+	if report.Pass {
+		fmt.Printf("scenario %q: PASS\n", report.Name)
+		return
 	}
 
+	fmt.Printf("scenario %q: FAIL\n", report.Name)
+	os.Exit(1)
 }